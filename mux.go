@@ -3,10 +3,15 @@ package mux
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type key int
@@ -24,36 +29,433 @@ var (
 
 	// ErrUnknownTypeInRoute is returned when something unexpected is passed to a route function.
 	ErrUnknownTypeInRoute = errors.New("mux: unexpected type passed to route")
+
+	// ErrConflictingPattern is returned when a route is registered whose constrained segment collides with an
+	// already-registered route for the same method, path shape, and parameter name but a different pattern.
+	ErrConflictingPattern = errors.New("mux: route conflicts with an already registered pattern")
+)
+
+// Logger is the interface the mux uses for its own internal tracing and diagnostics. Plug in whatever structured
+// logger your application already uses via Mux.SetLogger; a Mux that hasn't had one set logs nothing.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// noopLogger is the default Logger, used until SetLogger is called. It discards everything.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(format string, args ...interface{}) {}
+func (noopLogger) Infof(format string, args ...interface{})  {}
+func (noopLogger) Warnf(format string, args ...interface{})  {}
+func (noopLogger) Errorf(format string, args ...interface{}) {}
+
+// StdLogger adapts the standard library's *log.Logger to the Logger interface, prefixing each line with its level so
+// the mux's own tracing is easy to pick out of a larger log, e.g. m.SetLogger(mux.NewStdLogger(log.Default())).
+type StdLogger struct {
+	l *log.Logger
+}
+
+// NewStdLogger returns a Logger which writes every level to l.
+func NewStdLogger(l *log.Logger) *StdLogger {
+	return &StdLogger{l: l}
+}
+
+func (s *StdLogger) Debugf(format string, args ...interface{}) { s.l.Printf("DEBUG "+format, args...) }
+func (s *StdLogger) Infof(format string, args ...interface{})  { s.l.Printf("INFO "+format, args...) }
+func (s *StdLogger) Warnf(format string, args ...interface{})  { s.l.Printf("WARN "+format, args...) }
+func (s *StdLogger) Errorf(format string, args ...interface{}) { s.l.Printf("ERROR "+format, args...) }
+
+// segmentKind describes what a path Segment matches against.
+type segmentKind int
+
+const (
+	// segStatic matches the raw text of the segment exactly, e.g. "users" in "/users/{id}".
+	segStatic segmentKind = iota
+	// segPlaceholder matches any non-empty value and captures it under Name, e.g. ":id" or "{id}".
+	segPlaceholder
+	// segPattern matches only values accepted by Pattern and captures them under Name, e.g. "{id:[0-9]+}".
+	segPattern
+	// segCatchAll matches the rest of the path (this segment and everything after it, joined with "/") and captures
+	// it under Name. It's only valid as the final segment of a path, e.g. "*rest" in "/s/*rest".
+	segCatchAll
 )
 
+// namedPatterns are the built-in type hints recognised in a "{name:kind}" segment, so common constraints don't need
+// to be spelled out as raw regexps every time.
+var namedPatterns = map[string]string{
+	"int":  `^[0-9]+$`,
+	"uuid": `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+}
+
+var uuidPattern = regexp.MustCompile(namedPatterns["uuid"])
+
+// Segment is a single "/"-delimited piece of a registered path, pre-parsed and (if it carries a constraint)
+// pre-compiled at registration time so routing never has to parse or compile a pattern per-request.
+type Segment struct {
+	Raw     string
+	Kind    segmentKind
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// parseSegment turns one raw "/"-delimited path segment into a Segment, recognising these forms:
+//
+//   - "users"            -> static, matched literally
+//   - ":id"               -> placeholder, matches anything and is captured as "id"
+//   - "{id}"              -> same as ":id"
+//   - "{id:int}"          -> placeholder constrained to the built-in "int" pattern
+//   - "{id:[0-9]+}"       -> placeholder constrained to the given regexp
+//   - "*rest"             -> catch-all, captures the rest of the path as "rest"
+func parseSegment(raw string) (Segment, error) {
+	if raw == "" {
+		return Segment{Raw: raw, Kind: segStatic}, nil
+	}
+
+	if raw[0] == ':' {
+		if len(raw) == 1 {
+			return Segment{}, fmt.Errorf("mux: empty parameter name in segment %q", raw)
+		}
+		return Segment{Raw: raw, Kind: segPlaceholder, Name: raw[1:]}, nil
+	}
+
+	if raw[0] == '*' {
+		if len(raw) == 1 {
+			return Segment{}, fmt.Errorf("mux: empty parameter name in segment %q", raw)
+		}
+		return Segment{Raw: raw, Kind: segCatchAll, Name: raw[1:]}, nil
+	}
+
+	if raw[0] == '{' && raw[len(raw)-1] == '}' {
+		inner := raw[1 : len(raw)-1]
+
+		name := inner
+		constraint := ""
+		if idx := strings.Index(inner, ":"); idx >= 0 {
+			name = inner[:idx]
+			constraint = inner[idx+1:]
+		}
+
+		if name == "" {
+			return Segment{}, fmt.Errorf("mux: empty parameter name in segment %q", raw)
+		}
+
+		if constraint == "" {
+			return Segment{Raw: raw, Kind: segPlaceholder, Name: name}, nil
+		}
+
+		if known, ok := namedPatterns[constraint]; ok {
+			constraint = known
+		} else {
+			constraint = "^(?:" + constraint + ")$"
+		}
+
+		re, err := regexp.Compile(constraint)
+		if err != nil {
+			return Segment{}, fmt.Errorf("mux: invalid pattern in segment %q: %s", raw, err)
+		}
+
+		return Segment{Raw: raw, Kind: segPattern, Name: name, Pattern: re}, nil
+	}
+
+	return Segment{Raw: raw, Kind: segStatic}, nil
+}
+
+// parseSegments splits a path on "/" (dropping the leading empty element) and parses each piece in turn.
+func parseSegments(path string) ([]Segment, error) {
+	parts := strings.Split(path, "/")[1:]
+	segments := make([]Segment, len(parts))
+
+	for i, part := range parts {
+		segment, err := parseSegment(part)
+		if err != nil {
+			return nil, err
+		}
+		if segment.Kind == segCatchAll && i != len(parts)-1 {
+			return nil, fmt.Errorf("mux: catch-all segment %q must be the last segment of the path", part)
+		}
+		segments[i] = segment
+	}
+
+	return segments, nil
+}
+
+// parseHostPattern turns a dotted host pattern such as "{sub}.example.com" into a compiled regexp anchored to the
+// whole host, with each "{name}" label becoming a named capture group matched against a single label ("[^.]+").
+func parseHostPattern(pattern string) (*regexp.Regexp, error) {
+	labels := strings.Split(pattern, ".")
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteString(`\.`)
+		}
+		if len(label) >= 2 && label[0] == '{' && label[len(label)-1] == '}' {
+			name := label[1 : len(label)-1]
+			b.WriteString("(?P<")
+			b.WriteString(name)
+			b.WriteString(">[^.]+)")
+		} else {
+			b.WriteString(regexp.QuoteMeta(label))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// conflictsWith reports whether segments collides with an already-registered route's segments: same method, same
+// shape, same parameter name at some position, but a different pattern. Routes that merely differ in their static
+// text (e.g. "/users/me" vs "/users/{id:[0-9]+}") are not conflicts since they're both still unambiguous to match.
+func conflictsWith(method string, segments []Segment, routes []*Route) error {
+	for _, route := range routes {
+		if route.Method != method || route.Length != len(segments) {
+			continue
+		}
+
+		// a static segment whose text differs means no request can match both routes, so they can't conflict
+		disjoint := false
+		for i, segment := range segments {
+			if segment.Kind == segStatic && route.Segments[i].Kind == segStatic && segment.Raw != route.Segments[i].Raw {
+				disjoint = true
+				break
+			}
+		}
+		if disjoint {
+			continue
+		}
+
+		for i, segment := range segments {
+			existing := route.Segments[i]
+
+			if segment.Kind != segPattern || existing.Kind != segPattern {
+				continue
+			}
+
+			if segment.Name != existing.Name {
+				continue
+			}
+
+			if segment.Pattern.String() != existing.Pattern.String() {
+				return fmt.Errorf("%w: %q already registers {%s:...} with a different pattern than %q", ErrConflictingPattern, route.Path, segment.Name, segment.Raw)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Route is an internal "method + path + middlewares + handler" type created when each route is added. When adding a
 // handler for Get(), Post(), Put(), Delete(), Options(), and Patch(), the middlewares prior to this route (and any on
 // this route) are combined to create the final handler.
 //
 // These are not computed during routing but when added to the router, therefore they have negligible overhead.
+//
+// Get(), Post(), and friends always return a non-nil *Route, even when registration failed: check Err rather than
+// expecting a panic. (A path that doesn't begin with "/" is the one exception: add still panics on it.) Err is also what the fluent matcher methods (Host, Schemes, Headers, Queries) set and check, so
+// a bad call anywhere in the chain is reported once, at the end, instead of panicking partway through.
 type Route struct {
 	Method      string
 	Path        string
-	Segments    []string
+	Segments    []Segment
 	Length      int
 	Middlewares []func(http.Handler) http.Handler
 	Handler     http.Handler
+
+	// Err is set if this route failed to register or if a fluent matcher method below was given bad arguments.
+	Err error
+
+	hostPattern *regexp.Regexp
+	schemes     []string
+	headers     [][2]string
+	queries     [][2]string
+}
+
+// Host constrains the route to requests whose Host header matches pattern. pattern is a dotted host name where any
+// label of the form "{name}" matches a single label and populates Vals under "name", e.g. Host("{tenant}.example.com").
+func (route *Route) Host(pattern string) *Route {
+	if route.Err != nil {
+		return route
+	}
+
+	re, err := parseHostPattern(pattern)
+	if err != nil {
+		route.Err = fmt.Errorf("mux: invalid host pattern %q: %s", pattern, err)
+		return route
+	}
+
+	route.hostPattern = re
+	return route
+}
+
+// Schemes constrains the route to requests using one of the given URL schemes (e.g. "https").
+func (route *Route) Schemes(s ...string) *Route {
+	if route.Err != nil {
+		return route
+	}
+
+	route.schemes = append(route.schemes, s...)
+	return route
+}
+
+// Headers constrains the route to requests carrying every given header. kv is a list of alternating header name and
+// required value, e.g. Headers("X-Api-Version", "2").
+func (route *Route) Headers(kv ...string) *Route {
+	if route.Err != nil {
+		return route
+	}
+
+	if len(kv)%2 != 0 {
+		route.Err = errors.New("mux: Headers requires an even number of key/value arguments")
+		return route
+	}
+
+	for i := 0; i < len(kv); i += 2 {
+		route.headers = append(route.headers, [2]string{kv[i], kv[i+1]})
+	}
+	return route
+}
+
+// Queries constrains the route to requests carrying every given query parameter. kv is a list of alternating
+// parameter name and required value, e.g. Queries("format", "json").
+func (route *Route) Queries(kv ...string) *Route {
+	if route.Err != nil {
+		return route
+	}
+
+	if len(kv)%2 != 0 {
+		route.Err = errors.New("mux: Queries requires an even number of key/value arguments")
+		return route
+	}
+
+	for i := 0; i < len(kv); i += 2 {
+		route.queries = append(route.queries, [2]string{kv[i], kv[i+1]})
+	}
+	return route
+}
+
+// matchesExtras evaluates the route's Host, Schemes, Headers, and Queries predicates (if any) against r, once the
+// path itself has already matched. Any host pattern variables are only added to vals once every predicate has
+// passed - when two routes share a terminal tree node and differ only by these matchers, a route rejected partway
+// through must not leave its host captures behind for whichever sibling route ends up matching instead.
+func (route *Route) matchesExtras(r *http.Request, vals *valsCarrier) bool {
+	var hostVals [][2]string
+
+	if route.hostPattern != nil {
+		host := r.Host
+		if i := strings.IndexByte(host, ':'); i >= 0 {
+			host = host[:i]
+		}
+
+		m := route.hostPattern.FindStringSubmatch(host)
+		if m == nil {
+			return false
+		}
+
+		for i, name := range route.hostPattern.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			hostVals = append(hostVals, [2]string{name, m[i]})
+		}
+	}
+
+	if len(route.schemes) > 0 {
+		scheme := r.URL.Scheme
+		if r.TLS != nil {
+			scheme = "https"
+		} else if scheme == "" {
+			scheme = "http"
+		}
+
+		matched := false
+		for _, s := range route.schemes {
+			if strings.EqualFold(s, scheme) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, h := range route.headers {
+		if r.Header.Get(h[0]) != h[1] {
+			return false
+		}
+	}
+
+	for _, q := range route.queries {
+		if r.URL.Query().Get(q[0]) != q[1] {
+			return false
+		}
+	}
+
+	for _, hv := range hostVals {
+		vals.set(hv[0], hv[1])
+	}
+
+	return true
 }
 
 // Prefix is an internal "path + middlewares" type created when each middleware prefix is added. When adding we'll
 // add the middlewares to the array of Middlewares.
 type Prefix struct {
 	Path        string
-	Segments    []string
+	Segments    []Segment
 	Length      int
 	Middlewares []func(http.Handler) http.Handler
 	Handler     http.Handler
 }
 
+// mount is an internal "path + handler" type created by Mount, for delegating a whole subtree to an external
+// http.Handler rather than matching it against our own routes.
+type mount struct {
+	Path     string
+	Segments []Segment
+	Length   int
+	Handler  http.Handler
+}
+
+// radixNode is one node of the per-method radix tree built by Compile, keyed by path segment: static children are
+// looked up by exact text, dynamic children (placeholders and patterns) are tried in registration order, and a
+// catch-all child (if any) absorbs everything else. A node can hold more than one terminal route when routes only
+// differ by a Host/Schemes/Headers/Queries matcher rather than by path.
+type radixNode struct {
+	static   map[string]*radixNode
+	dynamic  []*radixNode
+	seg      *Segment
+	catchAll *radixNode
+	routes   []*Route
+}
+
 // Mux is just an array of Route.
 type Mux struct {
-	routes   []Route
+	routes   []*Route
 	prefixes []Prefix
+	mounts   []mount
+
+	// mountErr is the first error encountered while mounting a Group, Route, or Mount, surfaced via MountErr since
+	// Group and Route have no error return of their own.
+	mountErr error
+
+	// RedirectTrailingSlash, if true, makes a request that doesn't match any route but would match after adding or
+	// removing a trailing slash get redirected to that matching path instead of falling through to NotFound.
+	RedirectTrailingSlash bool
+
+	trees     map[string]*radixNode
+	compiled  bool
+	compileMu sync.Mutex
+
+	notFoundHandler         http.Handler
+	methodNotAllowedHandler http.Handler
+	panicHandler            func(http.ResponseWriter, *http.Request, interface{})
+
+	logger Logger
 }
 
 // Make sure the Mux conforms with the http.Handler interface.
@@ -61,43 +463,99 @@ var _ http.Handler = New()
 
 // New returns a new initialized Mux.  Nothing is automatic. You must do slash/non-slash redirection yourself.
 func New() *Mux {
-	return &Mux{}
+	return &Mux{panicHandler: defaultPanicHandler, logger: noopLogger{}}
 }
 
-// Get is a shortcut for mux.add("GET", path, things...)
-func (m *Mux) Get(path string, things ...interface{}) error {
-	log.Printf("NewGet()\n")
-	return m.add("GET", path, things...)
+// NotFound sets the handler called when no route, mount, or redirect matches a request. It defaults to
+// http.NotFound.
+func (m *Mux) NotFound(h http.Handler) {
+	m.notFoundHandler = h
+}
+
+// MethodNotAllowed sets the handler called when a request's path matches a route under a different method. The
+// Allow header is set before this handler runs. It defaults to a plain 405 response.
+func (m *Mux) MethodNotAllowed(h http.Handler) {
+	m.methodNotAllowedHandler = h
+}
+
+// PanicHandler sets the function called to recover a panic raised by a route, mount, or NotFound/MethodNotAllowed
+// handler, so library users don't have to wrap a recovery middleware around every handler themselves. It defaults to
+// logging the recovered value and writing a 500.
+func (m *Mux) PanicHandler(fn func(w http.ResponseWriter, r *http.Request, recovered interface{})) {
+	m.panicHandler = fn
+}
+
+// SetLogger sets the Logger used for this Mux's internal tracing and diagnostics. Passing nil restores the default,
+// which logs nothing.
+func (m *Mux) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = noopLogger{}
+	}
+	m.logger = logger
+}
+
+// defaultPanicHandler is used until PanicHandler overrides it.
+func defaultPanicHandler(w http.ResponseWriter, r *http.Request, recovered interface{}) {
+	http.Error(w, "500 internal server error", http.StatusInternalServerError)
+}
+
+// serve calls h, recovering any panic with m.panicHandler rather than letting it escape to the caller.
+func (m *Mux) serve(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			m.logger.Errorf("mux: recovered from panic serving %s %s: %v\n", r.Method, r.URL.Path, recovered)
+			m.panicHandler(w, r, recovered)
+		}
+	}()
+
+	h.ServeHTTP(w, r)
+}
+
+// Get is a shortcut for mux.add("GET", path, things...). The returned *Route is never nil: if registration failed,
+// its Err field is set rather than Get panicking or returning an error directly, so it can still be chained into
+// Host/Schemes/Headers/Queries without a nil check. A path that doesn't begin with "/" still panics.
+func (m *Mux) Get(path string, things ...interface{}) *Route {
+	return m.addRoute("GET", path, things...)
 }
 
 // Post is a shortcut for mux.add("POST", path, things...)
-func (m *Mux) Post(path string, things ...interface{}) {
-	m.add("POST", path, things...)
+func (m *Mux) Post(path string, things ...interface{}) *Route {
+	return m.addRoute("POST", path, things...)
 }
 
 // Put is a shortcut for mux.add("PUT", path, things...)
-func (m *Mux) Put(path string, things ...interface{}) {
-	m.add("PUT", path, things...)
+func (m *Mux) Put(path string, things ...interface{}) *Route {
+	return m.addRoute("PUT", path, things...)
 }
 
 // Patch is a shortcut for mux.add("PATCH", path, things...)
-func (m *Mux) Patch(path string, things ...interface{}) {
-	m.add("PATCH", path, things...)
+func (m *Mux) Patch(path string, things ...interface{}) *Route {
+	return m.addRoute("PATCH", path, things...)
 }
 
 // Delete is a shortcut for mux.add("DELETE", path, things...)
-func (m *Mux) Delete(path string, things ...interface{}) {
-	m.add("DELETE", path, things...)
+func (m *Mux) Delete(path string, things ...interface{}) *Route {
+	return m.addRoute("DELETE", path, things...)
 }
 
 // Options is a shortcut for mux.add("OPTIONS", path, things...)
-func (m *Mux) Options(path string, things ...interface{}) {
-	m.add("OPTIONS", path, things...)
+func (m *Mux) Options(path string, things ...interface{}) *Route {
+	return m.addRoute("OPTIONS", path, things...)
 }
 
 // Head is a shortcut for mux.add("HEAD", path, things...)
-func (m *Mux) Head(path string, things ...interface{}) {
-	m.add("HEAD", path, things...)
+func (m *Mux) Head(path string, things ...interface{}) *Route {
+	return m.addRoute("HEAD", path, things...)
+}
+
+// addRoute calls add and normalises its result into a non-nil *Route, so the HTTP-verb shortcuts above always have
+// something to return and chain matcher calls onto.
+func (m *Mux) addRoute(method, path string, things ...interface{}) *Route {
+	route, err := m.add(method, path, things...)
+	if err != nil {
+		return &Route{Method: method, Path: path, Err: err}
+	}
+	return route
 }
 
 // Use adds some middleware to a path prefix. Unlike other methods such as Get, Post, Put, Patch, and Delete, Use
@@ -108,7 +566,8 @@ func (m *Mux) Head(path string, things ...interface{}) {
 // Note however, m.Use("/profile/", ...) doesn't match "/profile" since it contains too many slashes. But
 // m.Use("/profile", ...) does match "/profile/" and "/profile/..." (but check that's actually what you want here).
 func (m *Mux) Use(path string, things ...interface{}) error {
-	return m.add("USE", path, things...)
+	_, err := m.add("USE", path, things...)
+	return err
 }
 
 // Prefix adds a handler to a path prefix. Unlike other methods such as Get, Post, Put, Patch, and Delete, All matches
@@ -116,74 +575,83 @@ func (m *Mux) Use(path string, things ...interface{}) error {
 //
 // e.g. m.Prefix("/s", ...) matches the requests "/s/img.png", "/s/css/styles.css", and "/s/js/app.js".
 func (m *Mux) Prefix(path string, things ...interface{}) error {
-	return m.add("PREFIX", path, things...)
+	_, err := m.add("PREFIX", path, things...)
+	return err
 }
 
-// add registers a new request handle with the given path and method.
+// add registers a new request handle with the given path and method, returning the *Route it created (nil for USE,
+// which only ever creates a Prefix).
 //
 // The respective shortcuts (for GET, POST, PUT, PATCH and DELETE) can also be used.
-func (m *Mux) add(method, path string, things ...interface{}) error {
-	log.Printf("--> add(): %s %s\n", method, path)
+func (m *Mux) add(method, path string, things ...interface{}) (*Route, error) {
+	m.logger.Infof("mux: add(): %s %s\n", method, path)
 
 	if path[0] != '/' {
 		panic("path must begin with '/' in path '" + path + "'")
 	}
 
+	// adding a route invalidates any previously compiled tree
+	m.compiled = false
+
 	if m.routes == nil {
-		m.routes = make([]Route, 0)
+		m.routes = make([]*Route, 0)
 	}
 
 	// collect up some things like the middlewares and the handler
 	var handler http.Handler
 	var middlewares []func(http.Handler) http.Handler
 
-	segments := strings.Split(path, "/")[1:]
+	segments, err := parseSegments(path)
+	if err != nil {
+		m.logger.Warnf("mux: add(): %s\n", err)
+		return nil, err
+	}
 
-	log.Printf("Things = %#v\n", things)
+	m.logger.Debugf("mux: add(): things = %#v\n", things)
 
 	for i, thing := range things {
-		log.Printf("Loop %d %#v\n", i, thing)
+		m.logger.Debugf("mux: add(): loop %d %#v\n", i, thing)
 		switch val := thing.(type) {
 		case func(http.Handler) http.Handler:
-			log.Printf("got func(http.Handler) http.Handler\n")
+			m.logger.Debugf("mux: add(): got func(http.Handler) http.Handler\n")
 			// if we already have a handler, then we should bork
 			if handler != nil {
-				log.Printf("returning ErrMiddlewareAfterHandler")
-				return ErrMiddlewareAfterHandler
+				m.logger.Warnf("mux: add(): returning ErrMiddlewareAfterHandler")
+				return nil, ErrMiddlewareAfterHandler
 			}
 			// all good, so add the middleware
-			log.Printf("adding to middlewares")
+			m.logger.Debugf("mux: add(): adding to middlewares")
 			middlewares = append(middlewares, val)
 		case http.Handler:
-			log.Printf("got http.Handler\n")
+			m.logger.Debugf("mux: add(): got http.Handler\n")
 			if handler != nil {
-				log.Printf("already got a handler")
-				return ErrMultipleHandlers
+				m.logger.Warnf("mux: add(): already got a handler")
+				return nil, ErrMultipleHandlers
 			}
 			// all good, so remember the handler
-			log.Printf("adding a handler")
+			m.logger.Debugf("mux: add(): adding a handler")
 			handler = val
 		case func(http.ResponseWriter, *http.Request):
-			log.Printf("got func(http.ResponseWriter, *http.Request)\n")
+			m.logger.Debugf("mux: add(): got func(http.ResponseWriter, *http.Request)\n")
 			if handler != nil {
-				log.Printf("already got a handler")
-				return ErrMultipleHandlers
+				m.logger.Warnf("mux: add(): already got a handler")
+				return nil, ErrMultipleHandlers
 			}
 			// all good, so remember the handler
-			log.Printf("adding a HandlerFunc")
+			m.logger.Debugf("mux: add(): adding a HandlerFunc")
 			handler = http.HandlerFunc(val)
 		default:
-			return ErrUnknownTypeInRoute
+			return nil, ErrUnknownTypeInRoute
 		}
 	}
 
-	log.Printf("add(): now adding to the handlers\n")
+	m.logger.Debugf("mux: add(): now adding to the handlers\n")
 
 	// If this is middleware, ie. USE, then there is nothing more to do, but if it is any other method, then we need to
 	// create the final handler from any prefix middleware prior to this, and any middleware AND handler for this route.
 	// If there is no handler for this route, then it is an error.
 	if method == "USE" {
-		log.Printf("mux: this is a USE prefix, nothing more to do here")
+		m.logger.Debugf("mux: add(): this is a USE prefix, nothing more to do here")
 		if handler != nil {
 			// this is not an error, since you might have a static server for a prefix, such as "/s"
 		}
@@ -197,84 +665,217 @@ func (m *Mux) add(method, path string, things ...interface{}) error {
 
 		// add  it to the middlewares
 		m.prefixes = append(m.prefixes, prefix)
-	} else {
-		// GET, PUT, PATCH, POST, DELETE, OPTIONS, HEAD, and PREFIX!
-
-		// generate our wrapped handler, wrapping each in reverse order from the current route, back down through each route
-		wrappedHandler := handler
-		for i := range middlewares {
-			log.Printf("- wrapping handler with middleware from route (m=%d)\n", i)
-			middleware := middlewares[len(middlewares)-1-i]
-			wrappedHandler = middleware(wrappedHandler)
-		}
-
-		// now, go in reverse order through each added middleware and do the same thing
-		for j := range m.prefixes {
-			log.Printf("- checking prefix %d to add middleware\n", j)
-			prefix := m.prefixes[len(m.prefixes)-1-j]
-
-			if isPrefixMatch(segments, &prefix) {
-				log.Printf("- this prefix matches this route\n")
-				// and again, get each middleware in reverse order
-				for i := range prefix.Middlewares {
-					log.Printf("- wrapping handler with middleware from prefix (m=%d)\n", i)
-					middleware := prefix.Middlewares[len(prefix.Middlewares)-1-i]
-					wrappedHandler = middleware(wrappedHandler)
-				}
+		return nil, nil
+	}
+
+	// GET, PUT, PATCH, POST, DELETE, OPTIONS, HEAD, and PREFIX!
+
+	if err := conflictsWith(method, segments, m.routes); err != nil {
+		m.logger.Warnf("mux: add(): %s\n", err)
+		return nil, err
+	}
+
+	// generate our wrapped handler, wrapping each in reverse order from the current route, back down through each route
+	wrappedHandler := handler
+	for i := range middlewares {
+		m.logger.Debugf("mux: add(): wrapping handler with middleware from route (m=%d)\n", i)
+		middleware := middlewares[len(middlewares)-1-i]
+		wrappedHandler = middleware(wrappedHandler)
+	}
+
+	// now wrap with any prefix middleware (from Use) that matches this route's path
+	wrappedHandler = m.wrapWithPrefixes(segments, wrappedHandler)
+
+	// create our handler which contains everything we need
+	route := &Route{
+		Method:      method,
+		Path:        path,
+		Segments:    segments,
+		Length:      len(segments),
+		Middlewares: nil, // we've already wrapped the handler
+		Handler:     wrappedHandler,
+	}
+
+	// add it to the route handlers
+	m.routes = append(m.routes, route)
+
+	m.logger.Debugf("mux: add(): routes=%#v\n", m.routes)
+	return route, nil
+}
+
+// wrapWithPrefixes wraps handler with the middleware of every registered Use prefix that matches segments, in the
+// same reverse-registration order as the middleware wrapping done in add().
+func (m *Mux) wrapWithPrefixes(segments []Segment, handler http.Handler) http.Handler {
+	wrapped := handler
+
+	for j := range m.prefixes {
+		m.logger.Debugf("mux: wrapWithPrefixes(): checking prefix %d to add middleware\n", j)
+		prefix := m.prefixes[len(m.prefixes)-1-j]
+
+		if isPrefixMatch(m.logger, segments, &prefix) {
+			m.logger.Debugf("mux: wrapWithPrefixes(): this prefix matches this route\n")
+			for i := range prefix.Middlewares {
+				m.logger.Debugf("mux: wrapWithPrefixes(): wrapping handler with middleware from prefix (m=%d)\n", i)
+				middleware := prefix.Middlewares[len(prefix.Middlewares)-1-i]
+				wrapped = middleware(wrapped)
 			}
 		}
+	}
 
-		// create our handler which contains everything we need
-		route := Route{
-			Method:      method,
-			Path:        path,
-			Segments:    segments,
-			Length:      len(segments),
-			Middlewares: nil, // we've already wrapped the handler
-			Handler:     wrappedHandler,
+	return wrapped
+}
+
+// joinPath joins a mount/group prefix with a path registered relative to it, collapsing the slash between them.
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimRight(prefix, "/")
+
+	if path == "/" {
+		if prefix == "" {
+			return "/"
 		}
+		return prefix
+	}
+
+	return prefix + path
+}
+
+// Group mounts a nested set of routes under prefix, sharing this Mux's middleware stack. fn is called with a fresh
+// child Mux; any routes, mounts, and Host/Schemes/Headers/Queries matchers it registers are copied onto m with
+// prefix prepended to their paths, and any of m's own Use middleware that matches the mounted path already wrapped
+// in - so large applications can avoid repeating a long prefix on every Get/Post call.
+//
+// Group itself has no error return to give you, since fn's job is to make further Get/Post/... calls rather than
+// return a value. If prefix is malformed or a mounted route conflicts with one already registered on m, the first
+// such error is recorded and can be checked afterwards with MountErr - the same "check Err instead of panicking"
+// convention Route's own fluent methods use.
+//
+// Route is provided as an alias, for readability when the nested routes represent a single resource.
+func (m *Mux) Group(prefix string, fn func(*Mux)) {
+	child := New()
+	fn(child)
+	if err := m.mountMux(prefix, child); err != nil && m.mountErr == nil {
+		m.mountErr = err
+	}
+}
+
+// Route is an alias for Group, for use when the nested routes represent a single resource, e.g.
+// m.Route("/users", func(r *mux.Mux) { ... }).
+func (m *Mux) Route(prefix string, fn func(*Mux)) {
+	m.Group(prefix, fn)
+}
+
+// MountErr returns the first error encountered while mounting a Group, Route, or Mount - a malformed prefix or a
+// route whose pattern conflicts with one already registered - or nil if none occurred. Group and Route have no
+// error return of their own to surface this through, so check MountErr once you're done registering routes.
+func (m *Mux) MountErr() error {
+	return m.mountErr
+}
 
-		// add it to the route handlers
-		m.routes = append(m.routes, route)
+// Mount delegates every request whose path begins with prefix to h, with prefix stripped from the URL before h sees
+// it. Use this for an entire external http.Handler (an admin panel, a filesystem server) rather than individual
+// routes. A mount is only consulted once no registered route has matched. Mount returns an error (rather than
+// panicking) if prefix is malformed.
+func (m *Mux) Mount(prefix string, h http.Handler) error {
+	segments, err := parseSegments(prefix)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("routes=%#v\n", m.routes)
+	m.mounts = append(m.mounts, mount{
+		Path:     prefix,
+		Segments: segments,
+		Length:   len(segments),
+		Handler:  http.StripPrefix(prefix, h),
+	})
 	return nil
 }
 
-func isPrefixMatch(segments []string, prefix *Prefix) bool {
-	log.Printf("isPrefixMatch: %v\n", segments)
+// mountMux copies every route and mount registered on child onto m, with prefix prepended to their paths and any of
+// m's Use middleware that matches the new, full path wrapped in. It returns the first error it hits - a malformed
+// path or a route whose pattern conflicts with one already registered on m - rather than panicking, stopping before
+// copying anything further.
+func (m *Mux) mountMux(prefix string, child *Mux) error {
+	m.compiled = false
+
+	for _, route := range child.routes {
+		fullPath := joinPath(prefix, route.Path)
+
+		segments, err := parseSegments(fullPath)
+		if err != nil {
+			return err
+		}
+
+		if err := conflictsWith(route.Method, segments, m.routes); err != nil {
+			return err
+		}
+
+		mounted := &Route{
+			Method:   route.Method,
+			Path:     fullPath,
+			Segments: segments,
+			Length:   len(segments),
+			Handler:  m.wrapWithPrefixes(segments, route.Handler),
+		}
+		mounted.hostPattern = route.hostPattern
+		mounted.schemes = route.schemes
+		mounted.headers = route.headers
+		mounted.queries = route.queries
+
+		m.routes = append(m.routes, mounted)
+	}
+
+	for _, mnt := range child.mounts {
+		fullPath := joinPath(prefix, mnt.Path)
+
+		segments, err := parseSegments(fullPath)
+		if err != nil {
+			return err
+		}
+
+		m.mounts = append(m.mounts, mount{
+			Path:     fullPath,
+			Segments: segments,
+			Length:   len(segments),
+			// mnt.Handler only strips the child's own mount path, so strip the group prefix in front of it first
+			Handler: http.StripPrefix(strings.TrimRight(prefix, "/"), mnt.Handler),
+		})
+	}
 
-	log.Printf("Checking against %#v\n", prefix)
+	return nil
+}
 
-	// if segments is just []string{''} (ie, from "/"), then this will match everything
-	if prefix.Length == 1 && prefix.Segments[0] == "" {
+// hasPrefix reports whether segments begins with prefixSegments, treating any placeholder or pattern segment in
+// prefixSegments as a wildcard. It's shared by isPrefixMatch (for Use/Prefix middleware) and Mount (for delegating a
+// subtree to an external http.Handler). logger is the calling Mux's logger, threaded through since hasPrefix has no
+// receiver of its own.
+func hasPrefix(logger Logger, segments []Segment, prefixSegments []Segment) bool {
+	// if prefixSegments is just []Segment{''} (ie, from "/"), then this will match everything
+	if len(prefixSegments) == 1 && prefixSegments[0].Raw == "" {
 		return true
 	}
 
 	// can't match if the prefix path length is longer than the URL
-	if prefix.Length > len(segments) {
+	if len(prefixSegments) > len(segments) {
 		return false
 	}
 
 	// check each segment is the same (for the length of the prefix)
-	for i, segment := range prefix.Segments {
-		log.Printf("isPrefixMatch: checking '%s' against '%s'\n", segments[i], segment)
+	for i, segment := range prefixSegments {
+		logger.Debugf("mux: hasPrefix(): checking '%s' against '%s'\n", segments[i].Raw, segment.Raw)
 
 		// if both segments are empty, then this matches
-		if segment == "" && segments[i] == "" {
-			log.Printf(" - both empty, fine\n")
+		if segment.Raw == "" && segments[i].Raw == "" {
+			logger.Debugf("mux: hasPrefix(): both empty, fine\n")
 			continue
 		}
 
-		// check if segment start with a ":"
-		if segment[0:0] == ":" {
-			log.Printf("Placeholder = %s\n", segment)
+		if segment.Kind == segPlaceholder || segment.Kind == segPattern {
+			logger.Debugf("mux: hasPrefix(): placeholder = %s\n", segment.Raw)
 			continue
 		}
 
-		if segments[i] != segment {
-			log.Printf(" - not the same, this prefix doesn't match\n")
+		if segments[i].Raw != segment.Raw {
+			logger.Debugf("mux: hasPrefix(): not the same, this prefix doesn't match\n")
 			return false
 		}
 	}
@@ -283,59 +884,218 @@ func isPrefixMatch(segments []string, prefix *Prefix) bool {
 	return true
 }
 
-func isMatch(method string, segments []string, route *Route) (map[string]string, bool) {
-	log.Printf("isMatch: %s %v\n", method, segments)
+// isPrefixMatch reports whether segments is matched by prefix, logging via logger (the calling Mux's logger).
+func isPrefixMatch(logger Logger, segments []Segment, prefix *Prefix) bool {
+	logger.Debugf("mux: isPrefixMatch(): segments=%v against prefix=%#v\n", segments, prefix)
 
-	// can't match if the methods are different
-	if route.Method != method {
-		log.Printf("isMatch: different method (got %s, this route is %s)\n", method, route.Method)
-		return nil, false
+	return hasPrefix(logger, segments, prefix.Segments)
+}
+
+// Compile builds the per-method radix trees used to route requests. It's optional: ServeHTTP calls it itself (via
+// ensureCompiled) the first time it's needed. Call it explicitly (after all routes are registered, before serving
+// traffic) to pay that cost up-front instead of on the first request. Compile itself isn't safe to call
+// concurrently with route registration (Get, Post, Group, ...) or with other calls to Compile, the same as any other
+// registration-time method on Mux - register your routes, then either call Compile or start serving.
+func (m *Mux) Compile() {
+	trees := make(map[string]*radixNode, len(m.routes))
+
+	for _, route := range m.routes {
+		root, ok := trees[route.Method]
+		if !ok {
+			root = &radixNode{}
+			trees[route.Method] = root
+		}
+		insertRoute(root, route)
+	}
+
+	m.trees = trees
+	m.compiled = true
+}
+
+// ensureCompiled builds the radix trees on the first call if they haven't been built yet, guarded by compileMu so
+// that concurrent requests landing before anyone calls Compile explicitly don't race building it.
+func (m *Mux) ensureCompiled() {
+	m.compileMu.Lock()
+	defer m.compileMu.Unlock()
+
+	if !m.compiled {
+		m.Compile()
+	}
+}
+
+// insertRoute walks route's segments down root, creating nodes as needed, and appends route to the routes at the
+// resulting terminal node. A static, placeholder/pattern, and catch-all child can all coexist at the same position -
+// lookup always tries the static child first, then the dynamic children in registration order, then the catch-all,
+// so a literal path like "/users/me" and a parameterised sibling like "/users/{id}" are both reachable rather than
+// one registration conflicting with the other.
+func insertRoute(root *radixNode, route *Route) {
+	cur := root
+
+	for _, seg := range route.Segments {
+		switch seg.Kind {
+		case segStatic:
+			if cur.static == nil {
+				cur.static = make(map[string]*radixNode)
+			}
+			child, ok := cur.static[seg.Raw]
+			if !ok {
+				child = &radixNode{}
+				cur.static[seg.Raw] = child
+			}
+			cur = child
+
+		case segPlaceholder, segPattern:
+			var child *radixNode
+			for _, d := range cur.dynamic {
+				if d.seg.Kind == seg.Kind && d.seg.Name == seg.Name && samePattern(d.seg, &seg) {
+					child = d
+					break
+				}
+			}
+			if child == nil {
+				s := seg
+				child = &radixNode{seg: &s}
+				cur.dynamic = append(cur.dynamic, child)
+			}
+			cur = child
+
+		case segCatchAll:
+			if cur.catchAll == nil {
+				s := seg
+				cur.catchAll = &radixNode{seg: &s}
+			}
+			cur = cur.catchAll
+		}
+	}
+
+	cur.routes = append(cur.routes, route)
+}
+
+// samePattern reports whether two placeholder/pattern segments impose the same constraint, so registering the same
+// "{id:int}" twice (e.g. via two Group calls) reuses one tree node instead of branching pointlessly.
+func samePattern(a, b *Segment) bool {
+	if a.Pattern == nil && b.Pattern == nil {
+		return true
+	}
+	if a.Pattern == nil || b.Pattern == nil {
+		return false
 	}
+	return a.Pattern.String() == b.Pattern.String()
+}
 
-	// can't match if the url length is different from the route length
-	if route.Length != len(segments) {
-		log.Printf("isMatch: different path length (got %d, this route is %d long)\n", len(segments), route.Length)
+// lookup walks segments from idx, trying static children first, then dynamic children in registration order, then a
+// catch-all, and returns the first terminal route for which accept returns true - backtracking to the next
+// alternative otherwise. vals accumulates placeholder/pattern/catch-all captures as it descends.
+func (n *radixNode) lookup(segments []Segment, idx int, vals *valsCarrier, accept func(*Route) bool) (*Route, bool) {
+	if idx == len(segments) {
+		for _, route := range n.routes {
+			if accept(route) {
+				return route, true
+			}
+		}
 		return nil, false
 	}
 
-	vals := make(map[string]string)
+	raw := segments[idx].Raw
 
-	// check each segment is the same (for the length of the prefix)
-	for i, segment := range route.Segments {
-		log.Printf("isMatch: checking '%s' against '%s'\n", segments[i], segment)
+	if child, ok := n.static[raw]; ok {
+		if route, ok := child.lookup(segments, idx+1, vals, accept); ok {
+			return route, true
+		}
+	}
 
-		// if both segments are empty, then this matches
-		if segment == "" && segments[i] == "" {
-			log.Printf(" - both empty, fine\n")
+	for _, d := range n.dynamic {
+		if d.seg.Kind == segPattern && !d.seg.Pattern.MatchString(raw) {
 			continue
 		}
 
-		// check if segment start with a ":"
-		if segment != "" && segment[0:1] == ":" {
-			log.Printf("Placeholder = %s\n", segment)
-			// ToDo: store/return this value somewhere
-			vals[segment[1:]] = segments[i]
-			continue
+		prev, had := vals.get(d.seg.Name)
+		vals.set(d.seg.Name, raw)
+
+		if route, ok := d.lookup(segments, idx+1, vals, accept); ok {
+			return route, true
 		}
 
-		if segments[i] != segment {
-			return nil, false
+		if had {
+			vals.set(d.seg.Name, prev)
+		} else {
+			vals.unset(d.seg.Name)
 		}
 	}
 
-	// nothing stopped us from matching, so it must be true
-	return vals, true
+	if n.catchAll != nil {
+		rest := make([]string, len(segments)-idx)
+		for i, s := range segments[idx:] {
+			rest[i] = s.Raw
+		}
+		vals.set(n.catchAll.seg.Name, strings.Join(rest, "/"))
+
+		for _, route := range n.catchAll.routes {
+			if accept(route) {
+				return route, true
+			}
+		}
+
+		vals.unset(n.catchAll.seg.Name)
+	}
+
+	return nil, false
+}
+
+// allowedMethods returns, sorted, every HTTP method other than exclude whose tree has a route matching segments
+// (ignoring Host, Schemes, Headers, and Queries), for building a 405 response's Allow header. exclude is always the
+// request's own method: its tree has already been checked (with the extras applied) by the time allowedMethods is
+// called, so a path match there that was rejected on Host/Schemes/Headers/Queries must not also be reported as
+// "allowed" - that's a 404 on this method, not a 405.
+func (m *Mux) allowedMethods(segments []Segment, exclude string) []string {
+	var methods []string
+
+	for method, root := range m.trees {
+		// PREFIX is the pseudo-method Prefix registers under, not an HTTP method a client could retry with
+		if method == exclude || method == "PREFIX" {
+			continue
+		}
+		var vals valsCarrier
+		if _, ok := root.lookup(segments, 0, &vals, func(*Route) bool { return true }); ok {
+			methods = append(methods, method)
+		}
+	}
+
+	sort.Strings(methods)
+	return methods
+}
+
+// buildRequestSegments turns a cleaned request path into the []Segment shape the tree and mount lookups expect.
+func buildRequestSegments(normPath string) []Segment {
+	raw := strings.Split(normPath, "/")[1:]
+	segments := make([]Segment, len(raw))
+	for i, s := range raw {
+		segments[i] = Segment{Raw: s, Kind: segStatic}
+	}
+	return segments
+}
+
+// toggleTrailingSlash returns normPath with its trailing slash added or removed, for RedirectTrailingSlash to probe
+// the alternative form. "/" is returned unchanged since it has no non-slash form.
+func toggleTrailingSlash(normPath string) string {
+	if normPath == "/" {
+		return normPath
+	}
+	if strings.HasSuffix(normPath, "/") {
+		return strings.TrimSuffix(normPath, "/")
+	}
+	return normPath + "/"
 }
 
 // ServeHTTP
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log.Printf("--- NEW REQUEST %s %s ---\n", r.Method, r.URL.Path)
+	m.logger.Debugf("mux: --- new request %s %s ---\n", r.Method, r.URL.Path)
 
 	method := r.Method
 	normPath := path.Clean(r.URL.Path)
-	log.Printf("request: method=%#v\n", method)
-	log.Printf(" - r.URL.Path = %#v\n", r.URL.Path)
-	log.Printf(" - normalised = %#v\n", normPath)
+	m.logger.Debugf("mux: request: method=%#v\n", method)
+	m.logger.Debugf("mux: request: r.URL.Path = %#v\n", r.URL.Path)
+	m.logger.Debugf("mux: request: normalised = %#v\n", normPath)
 
 	// if the original path ends in a slash
 	if normPath != "/" {
@@ -344,7 +1104,7 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf(" - normalised = %#v\n", normPath)
+	m.logger.Debugf("mux: request: normalised = %#v\n", normPath)
 
 	// if these paths differ, then redirect to the real one
 	if normPath != r.URL.Path {
@@ -352,40 +1112,199 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("request: split=%#v\n", strings.Split(normPath, "/"))
-	segments := strings.Split(normPath, "/")[1:]
+	m.logger.Debugf("mux: request: split=%#v\n", strings.Split(normPath, "/"))
+	segments := buildRequestSegments(normPath)
+	m.logger.Debugf("mux: request: segments=%#v\n", segments)
 
-	log.Printf("request: segments=%#v\n", segments)
+	m.ensureCompiled()
 
-	for i, route := range m.routes {
-		log.Printf("--- Route(%d): %s /%s\n", i, route.Method, strings.Join(route.Segments, "/"))
+	if root, ok := m.trees[method]; ok {
+		var vals valsCarrier
+		route, matched := root.lookup(segments, 0, &vals, func(route *Route) bool {
+			return route.matchesExtras(r, &vals)
+		})
+		if matched {
+			m.logger.Debugf("mux: match: placeholder vals = %#v\n", vals)
 
-		// ToDo: check for a prefix match for things like m.Prefix("/s", http.FileServer(http.Dir("static")))
-		vals, matched := isMatch(method, segments, &route)
-		if matched == false {
-			log.Printf("NO match")
-			continue
+			// a route with no placeholders never touches vals, so it never needs boxing into the context
+			if !vals.empty() {
+				ctx := context.WithValue(r.Context(), valsIdKey, &vals)
+				r = r.WithContext(ctx)
+			}
+
+			m.logger.Debugf("mux: == before handler\n")
+			m.serve(route.Handler, w, r)
+			m.logger.Debugf("mux: == after handler\n")
+			return
 		}
+	}
 
-		log.Printf("Match: placeholder vals = %#v\n", vals)
+	// no route matched, so see if a mounted subtree handler wants this path before giving up
+	for _, mnt := range m.mounts {
+		if hasPrefix(m.logger, segments, mnt.Segments) {
+			m.serve(mnt.Handler, w, r)
+			return
+		}
+	}
 
-		// save these placeholders into the context (even if empty)
-		ctx := context.WithValue(r.Context(), valsIdKey, vals)
-		r = r.WithContext(ctx)
+	// the path matches under a different method, so this is a 405, not a 404
+	if methods := m.allowedMethods(segments, method); len(methods) > 0 {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		if m.methodNotAllowedHandler != nil {
+			m.serve(m.methodNotAllowedHandler, w, r)
+			return
+		}
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-		// and call the handler
-		log.Printf("== before handler\n")
-		route.Handler.ServeHTTP(w, r)
-		log.Printf("== after handler\n")
+	if m.RedirectTrailingSlash {
+		altPath := toggleTrailingSlash(normPath)
+		if altPath != normPath {
+			if root, ok := m.trees[method]; ok {
+				var altVals valsCarrier
+				if _, ok := root.lookup(buildRequestSegments(altPath), 0, &altVals, func(*Route) bool { return true }); ok {
+					http.Redirect(w, r, altPath, http.StatusMovedPermanently)
+					return
+				}
+			}
+		}
+	}
 
-		// nothing else to do, so stop multiple matches and multiple response.WriteHeader calls
+	// If we got through to here, then no route matched.
+	if m.notFoundHandler != nil {
+		m.serve(m.notFoundHandler, w, r)
 		return
 	}
-
-	// If we got through to here, then not route matched, so just call NotFound.
 	http.NotFound(w, r)
 }
 
+// valsCarrierInline is the number of placeholder/pattern/catch-all/host captures a valsCarrier holds inline, without
+// a map. The overwhelming majority of routes capture one or two values, so this covers the hot path.
+const valsCarrierInline = 4
+
+// valsCarrier accumulates a route's captures as the radix tree's lookup descends, without allocating a
+// map[string]string for every request. A route with no placeholders never calls set, so its carrier is never
+// boxed into the request context at all; Vals and Val materialize it into the public map[string]string shape only
+// when a handler actually asks for one.
+type valsCarrier struct {
+	n        int
+	keys     [valsCarrierInline]string
+	values   [valsCarrierInline]string
+	overflow map[string]string
+}
+
+func (c *valsCarrier) get(key string) (string, bool) {
+	for i := 0; i < c.n; i++ {
+		if c.keys[i] == key {
+			return c.values[i], true
+		}
+	}
+	if c.overflow != nil {
+		v, ok := c.overflow[key]
+		return v, ok
+	}
+	return "", false
+}
+
+func (c *valsCarrier) set(key, value string) {
+	for i := 0; i < c.n; i++ {
+		if c.keys[i] == key {
+			c.values[i] = value
+			return
+		}
+	}
+	if c.overflow != nil {
+		if _, ok := c.overflow[key]; ok {
+			c.overflow[key] = value
+			return
+		}
+	}
+	if c.n < valsCarrierInline {
+		c.keys[c.n] = key
+		c.values[c.n] = value
+		c.n++
+		return
+	}
+	if c.overflow == nil {
+		c.overflow = make(map[string]string)
+	}
+	c.overflow[key] = value
+}
+
+// unset removes key, restoring the carrier to how it looked before key was set - used by lookup's backtracking.
+func (c *valsCarrier) unset(key string) {
+	for i := 0; i < c.n; i++ {
+		if c.keys[i] == key {
+			copy(c.keys[i:c.n-1], c.keys[i+1:c.n])
+			copy(c.values[i:c.n-1], c.values[i+1:c.n])
+			c.n--
+			return
+		}
+	}
+	if c.overflow != nil {
+		delete(c.overflow, key)
+	}
+}
+
+// empty reports whether the carrier has never had a value set, so ServeHTTP can skip boxing it into the request
+// context entirely for routes with no placeholders.
+func (c *valsCarrier) empty() bool {
+	return c.n == 0 && len(c.overflow) == 0
+}
+
+// materialize copies c into a map[string]string, the shape Vals has always returned.
+func (c *valsCarrier) materialize() map[string]string {
+	out := make(map[string]string, c.n+len(c.overflow))
+	for i := 0; i < c.n; i++ {
+		out[c.keys[i]] = c.values[i]
+	}
+	for k, v := range c.overflow {
+		out[k] = v
+	}
+	return out
+}
+
+// Vals returns the path placeholder, pattern, catch-all, and host values captured for the request's matched route.
+// It's only meaningful from within a handler (or its middleware) registered on this package's Mux. Building the map
+// is deferred until this is actually called; if the matched route captured nothing, it returns an empty map.
 func Vals(r *http.Request) map[string]string {
-	return r.Context().Value(valsIdKey).(map[string]string)
+	v, ok := r.Context().Value(valsIdKey).(*valsCarrier)
+	if !ok {
+		return map[string]string{}
+	}
+	return v.materialize()
+}
+
+// Val returns the single named value captured for the request's matched route, without materializing the full
+// Vals map. Prefer this over Vals(r)[name] when you only need one value.
+func Val(r *http.Request, name string) (string, bool) {
+	v, ok := r.Context().Value(valsIdKey).(*valsCarrier)
+	if !ok {
+		return "", false
+	}
+	return v.get(name)
+}
+
+// ValInt returns the named value as an int, for use with segments constrained to "{name:int}" or a custom numeric
+// pattern. It returns an error if the value is missing or isn't a valid integer.
+func ValInt(r *http.Request, name string) (int, error) {
+	val, ok := Val(r, name)
+	if !ok {
+		return 0, fmt.Errorf("mux: no value for %q", name)
+	}
+	return strconv.Atoi(val)
+}
+
+// ValUUID returns the named value validated as a UUID, for use with segments constrained to "{name:uuid}" or an
+// equivalent custom pattern. It returns an error if the value is missing or isn't a valid UUID.
+func ValUUID(r *http.Request, name string) (string, error) {
+	val, ok := Val(r, name)
+	if !ok {
+		return "", fmt.Errorf("mux: no value for %q", name)
+	}
+	if !uuidPattern.MatchString(val) {
+		return "", fmt.Errorf("mux: value %q for %q is not a valid UUID", val, name)
+	}
+	return val, nil
 }
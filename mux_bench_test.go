@@ -0,0 +1,51 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// benchMux builds a Mux with n static GET routes plus one route with a placeholder, so the tree always holds both
+// static and dynamic children regardless of n. benchmarkServeHTTP requests a static path; the dynamic lookup path is
+// exercised by BenchmarkServeHTTP_Placeholder.
+func benchMux(n int) *Mux {
+	m := New()
+	for i := 0; i < n; i++ {
+		m.Get(fmt.Sprintf("/route-%d/widgets", i), func(w http.ResponseWriter, r *http.Request) {})
+	}
+	m.Get("/route-0/widgets/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {})
+	m.Compile()
+	return m
+}
+
+func benchmarkServeHTTP(b *testing.B, n int) {
+	m := benchMux(n)
+	req := httptest.NewRequest("GET", fmt.Sprintf("/route-%d/widgets", n/2), nil)
+	rec := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(rec, req)
+	}
+}
+
+func BenchmarkServeHTTP_100Routes(b *testing.B)   { benchmarkServeHTTP(b, 100) }
+func BenchmarkServeHTTP_1000Routes(b *testing.B)  { benchmarkServeHTTP(b, 1000) }
+func BenchmarkServeHTTP_10000Routes(b *testing.B) { benchmarkServeHTTP(b, 10000) }
+
+// BenchmarkServeHTTP_Placeholder exercises the dynamic lookup path, so it's the one to watch for vals allocations:
+// one placeholder should cost at most the single *valsCarrier boxed into the request context.
+func BenchmarkServeHTTP_Placeholder(b *testing.B) {
+	m := benchMux(10)
+	req := httptest.NewRequest("GET", "/route-0/widgets/42", nil)
+	rec := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.ServeHTTP(rec, req)
+	}
+}
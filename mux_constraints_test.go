@@ -0,0 +1,65 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPatternConstraintMatch covers a {name:pattern} segment: it should only match values accepted by the pattern,
+// falling through to the next candidate (here, a plain placeholder route doesn't exist, so a non-numeric id 404s).
+func TestPatternConstraintMatch(t *testing.T) {
+	m := New()
+	m.Get("/widgets/{id:int}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := ValInt(r, "id")
+		w.Write([]byte(http.StatusText(http.StatusOK)))
+		_ = id
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets/42", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("numeric id: got %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets/abc", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("non-numeric id: got %d, want 404", rec.Code)
+	}
+}
+
+// TestUUIDConstraintMatch covers the built-in "uuid" named pattern end to end, via ValUUID.
+func TestUUIDConstraintMatch(t *testing.T) {
+	m := New()
+	m.Get("/widgets/{id:uuid}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ValUUID(r, "id"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets/550e8400-e29b-41d4-a716-446655440000", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid uuid: got %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets/not-a-uuid", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("invalid uuid: got %d, want 404", rec.Code)
+	}
+}
+
+// TestConflictingPatternRejected covers conflictsWith: the same method, shape, and parameter name with two different
+// patterns is a registration-time error, not something that's allowed to shadow silently.
+func TestConflictingPatternRejected(t *testing.T) {
+	m := New()
+	m.Get("/widgets/{id:int}", func(w http.ResponseWriter, r *http.Request) {})
+
+	route := m.Get("/widgets/{id:uuid}", func(w http.ResponseWriter, r *http.Request) {})
+	if route.Err == nil {
+		t.Fatal("expected Err to be set for a conflicting pattern, got nil")
+	}
+}
@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGroupMountsRoutesUnderPrefix covers the basic Group/Route behaviour: nested routes get the prefix prepended
+// to their path, and Route is a plain alias for Group.
+func TestGroupMountsRoutesUnderPrefix(t *testing.T) {
+	m := New()
+	m.Route("/users", func(r *Mux) {
+		r.Get("/", func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("list")) })
+		r.Get("/{id}", func(w http.ResponseWriter, req *http.Request) {
+			id, _ := Val(req, "id")
+			w.Write([]byte("show=" + id))
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "list" {
+		t.Fatalf("got %d %q", rec.Code, getBody(rec))
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "show=42" {
+		t.Fatalf("got %d %q", rec.Code, getBody(rec))
+	}
+}
+
+// TestGroupInheritsPrefixMiddleware covers that a Use prefix registered on the parent Mux before a Group is wrapped
+// around the group's mounted routes, the same as it would be around a route registered directly on the parent.
+func TestGroupInheritsPrefixMiddleware(t *testing.T) {
+	m := New()
+	var order []string
+	m.Use("/users", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "mw")
+			next.ServeHTTP(w, r)
+		})
+	})
+	m.Group("/users", func(r *Mux) {
+		r.Get("/{id}", func(w http.ResponseWriter, req *http.Request) { order = append(order, "handler") })
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+	if len(order) != 2 || order[0] != "mw" || order[1] != "handler" {
+		t.Fatalf("order = %v, want [mw handler]", order)
+	}
+}
+
+// TestGroupConflictingPatternSetsMountErr covers that a route mounted via Group/Route that conflicts with one
+// already registered on the parent is recorded on MountErr rather than panicking.
+func TestGroupConflictingPatternSetsMountErr(t *testing.T) {
+	m := New()
+	m.Get("/users/{id:int}", func(w http.ResponseWriter, r *http.Request) {})
+
+	m.Group("/users", func(r *Mux) {
+		r.Get("/{id:uuid}", func(w http.ResponseWriter, req *http.Request) {})
+	})
+
+	if m.MountErr() == nil {
+		t.Fatal("expected MountErr to be set for a conflicting pattern, got nil")
+	}
+}
+
+// TestMountDelegatesToHandler covers Mount: requests under prefix are delegated to the mounted handler with prefix
+// stripped, and only once no registered route has already matched.
+func TestMountDelegatesToHandler(t *testing.T) {
+	m := New()
+	m.Get("/admin/dashboard", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("dashboard")) })
+
+	mounted := http.NewServeMux()
+	mounted.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("settings")) })
+
+	if err := m.Mount("/admin", mounted); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/dashboard", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "dashboard" {
+		t.Fatalf("registered route: got %d %q", rec.Code, getBody(rec))
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/settings", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "settings" {
+		t.Fatalf("mounted route: got %d %q", rec.Code, getBody(rec))
+	}
+}
+
+// TestMountMalformedPrefixReturnsError covers that Mount reports a malformed prefix as an error rather than
+// panicking.
+func TestMountMalformedPrefixReturnsError(t *testing.T) {
+	m := New()
+	if err := m.Mount("/admin/*rest/more", http.NotFoundHandler()); err == nil {
+		t.Fatal("expected an error for a catch-all segment that isn't last, got nil")
+	}
+}
@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNotFoundHandler covers the pluggable NotFound hook overriding the http.NotFound default.
+func TestNotFoundHandler(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {})
+	m.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/nope", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("got %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+// TestMethodNotAllowedHandler covers the pluggable MethodNotAllowed hook, and that the Allow header is still set
+// before it runs.
+func TestMethodNotAllowedHandler(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {})
+	m.MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("POST", "/widgets", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("got %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET" {
+		t.Fatalf("Allow header = %q, want %q", allow, "GET")
+	}
+}
+
+// TestPanicHandlerRecovers covers the panic-recovery hook: a panicking handler must not escape ServeHTTP, and the
+// configured PanicHandler should run in its place with the recovered value.
+func TestPanicHandlerRecovers(t *testing.T) {
+	m := New()
+	m.Get("/boom", func(w http.ResponseWriter, r *http.Request) { panic("kaboom") })
+
+	var recovered interface{}
+	m.PanicHandler(func(w http.ResponseWriter, r *http.Request, rec interface{}) {
+		recovered = rec
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d, want 500", rec.Code)
+	}
+	if recovered != "kaboom" {
+		t.Fatalf("recovered = %v, want %q", recovered, "kaboom")
+	}
+}
+
+// TestDefaultPanicHandler covers that a panic is recovered even when no custom PanicHandler has been set.
+func TestDefaultPanicHandler(t *testing.T) {
+	m := New()
+	m.Get("/boom", func(w http.ResponseWriter, r *http.Request) { panic("kaboom") })
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d, want 500", rec.Code)
+	}
+}
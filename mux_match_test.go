@@ -0,0 +1,103 @@
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHostMatch covers the Host() matcher, including that a matched label is captured into Vals.
+func TestHostMatch(t *testing.T) {
+	m := New()
+	m.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ := Val(r, "tenant")
+		w.Write([]byte("tenant=" + tenant))
+	}).Host("{tenant}.example.com")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "acme.example.com"
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || getBody(rec) != "tenant=acme" {
+		t.Fatalf("got %d %q", rec.Code, getBody(rec))
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Host = "other.example.org"
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("non-matching host: got %d, want 404", rec.Code)
+	}
+}
+
+// TestSchemesMatch covers the Schemes() matcher against a request with a populated r.TLS, the way a real net/http
+// server delivers TLS traffic - r.URL.Scheme is almost always empty on the server side, so the scheme check must
+// consult r.TLS rather than relying on it.
+func TestSchemesMatch(t *testing.T) {
+	m := New()
+	m.Get("/secure", func(w http.ResponseWriter, r *http.Request) {}).Schemes("https")
+
+	req := httptest.NewRequest("GET", "/secure", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("TLS request: got %d, want 200", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/secure", nil)
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("plain request: got %d, want 404", rec.Code)
+	}
+}
+
+// TestHeadersAndQueriesMatch covers the Headers() and Queries() matchers together, since both gate on exact
+// key/value pairs read straight off the request.
+func TestHeadersAndQueriesMatch(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {}).Headers("X-Api-Version", "2").Queries("format", "json")
+
+	req := httptest.NewRequest("GET", "/widgets?format=json", nil)
+	req.Header.Set("X-Api-Version", "2")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("matching request: got %d, want 200", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/widgets?format=xml", nil)
+	req.Header.Set("X-Api-Version", "2")
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("wrong query: got %d, want 404", rec.Code)
+	}
+}
+
+// TestRejectedHostMatchDoesNotLeakVals covers a sibling-route leak: a route with both a Host pattern and a Headers
+// requirement that fails its Headers check must not leave its Host capture behind in vals for a plain sibling route
+// at the same path that ends up matching instead.
+func TestRejectedHostMatchDoesNotLeakVals(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "should not match", http.StatusInternalServerError)
+	}).Host("{tenant}.example.com").Headers("X-Api-Version", "2")
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := Val(r, "tenant"); ok {
+			http.Error(w, "leaked tenant val", http.StatusInternalServerError)
+			return
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/widgets", nil)
+	req.Host = "acme.example.com"
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %q, want 200", rec.Code, getBody(rec))
+	}
+}
@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func getBody(rec *httptest.ResponseRecorder) string {
+	return rec.Body.String()
+}
+
+// TestStaticAndPlaceholderSiblings covers a bug in the radix tree matcher: a literal route and a parameterised
+// sibling at the same position (e.g. "/users/me" and "/users/{id}") must both be reachable, not panic on the first
+// request that exercises the tree.
+func TestStaticAndPlaceholderSiblings(t *testing.T) {
+	m := New()
+	m.Get("/users/me", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("me")) })
+	m.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) { id, _ := Val(r, "id"); w.Write([]byte("id=" + id)) })
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users/me", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "me" {
+		t.Fatalf("static route: got %d %q", rec.Code, getBody(rec))
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/users/123", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "id=123" {
+		t.Fatalf("placeholder route: got %d %q", rec.Code, getBody(rec))
+	}
+}
+
+// TestStaticAndCatchAllSiblings is the same conflict but for a catch-all sibling, e.g. "/s/logo.png" vs "/s/*rest".
+func TestStaticAndCatchAllSiblings(t *testing.T) {
+	m := New()
+	m.Get("/s/logo.png", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("logo")) })
+	m.Get("/s/*rest", func(w http.ResponseWriter, r *http.Request) { rest, _ := Val(r, "rest"); w.Write([]byte("rest=" + rest)) })
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/s/logo.png", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "logo" {
+		t.Fatalf("static route: got %d %q", rec.Code, getBody(rec))
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/s/css/app.css", nil))
+	if rec.Code != http.StatusOK || getBody(rec) != "rest=css/app.css" {
+		t.Fatalf("catch-all route: got %d %q", rec.Code, getBody(rec))
+	}
+}
+
+// TestMethodNotAllowed covers the 405 path built on allowedMethods: a path that matches under a different method
+// should be reported with a 405 and an Allow header listing every method that does match, not a 404.
+func TestMethodNotAllowed(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {})
+	m.Post("/widgets", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("DELETE", "/widgets", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got %d, want 405", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, POST" {
+		t.Fatalf("Allow header = %q, want %q", allow, "GET, POST")
+	}
+}
+
+// TestMethodNotAllowedExcludesOwnMethod guards against a 405 being reported for the request's own method: a route
+// that matches the path under the request's method but is rejected on Host/Schemes/Headers/Queries must 404, not
+// 405, even though allowedMethods' path-only check would otherwise see a match for that method.
+func TestMethodNotAllowedExcludesOwnMethod(t *testing.T) {
+	m := New()
+	m.Get("/widgets", func(w http.ResponseWriter, r *http.Request) {}).Headers("X-Api-Version", "2")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got %d, want 404", rec.Code)
+	}
+}
+
+// TestConcurrentFirstRequestDoesNotRace exercises the lazy Compile() path from many goroutines at once, the way a
+// real http.Server calls ServeHTTP concurrently per-request. Run with -race to catch a build/lookup data race.
+func TestConcurrentFirstRequestDoesNotRace(t *testing.T) {
+	m := New()
+	m.Get("/widgets/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest("GET", "/widgets/42", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("got %d, want 200", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}